@@ -0,0 +1,88 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScopeInstanceSpec defines the desired state of ScopeInstance
+type ScopeInstanceSpec struct {
+	// ScopeTemplateName is the name of the ScopeTemplate that this instance
+	// requests ClusterRoles and bindings for.
+	ScopeTemplateName string `json:"scopeTemplateName"`
+
+	// Namespaces lists the namespaces that the generated ClusterRoles should
+	// be bound into via a RoleBinding. If empty, the ScopeInstance is
+	// considered cluster-scoped and a ClusterRoleBinding is generated
+	// instead.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Subjects lists the users, groups, and service accounts that the
+	// generated RoleBindings/ClusterRoleBinding grant the ScopeTemplate's
+	// ClusterRoles to. Subjects contributed by every ScopeInstance that
+	// targets the same ScopeTemplate and namespace are deduplicated into a
+	// single binding.
+	Subjects []rbacv1.Subject `json:"subjects"`
+}
+
+// ScopeInstanceStatus defines the observed state of ScopeInstance
+type ScopeInstanceStatus struct {
+	// Conditions describes the state of the ScopeInstance's generated
+	// bindings.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed by the
+	// controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// GeneratedBindings references the RoleBindings/ClusterRoleBinding
+	// currently generated for this ScopeInstance.
+	// +optional
+	GeneratedBindings []NamespacedName `json:"generatedBindings,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="BindingsReady")].status`
+//+kubebuilder:printcolumn:name="Degraded",type=string,JSONPath=`.status.conditions[?(@.type=="Degraded")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ScopeInstance is the Schema for the scopeinstances API
+type ScopeInstance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScopeInstanceSpec   `json:"spec,omitempty"`
+	Status ScopeInstanceStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScopeInstanceList contains a list of ScopeInstance
+type ScopeInstanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScopeInstance `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScopeInstance{}, &ScopeInstanceList{})
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterRoleTemplate describes a ClusterRole that is generated once any
+// ScopeInstance references the owning ScopeTemplate. A single ClusterRole is
+// shared by every referencing ScopeInstance.
+type ClusterRoleTemplate struct {
+	// GenerateName is used as the name of the generated ClusterRole and
+	// to track the ClusterRole across reconciles. It is immutable.
+	GenerateName string `json:"generateName"`
+
+	// Rules holds the PolicyRules for the generated ClusterRole.
+	Rules []rbacv1.PolicyRule `json:"rules"`
+}
+
+// ScopeTemplateSpec defines the desired state of ScopeTemplate
+type ScopeTemplateSpec struct {
+	// ClusterRoles is the set of ClusterRoles that is generated once any
+	// ScopeInstance references this ScopeTemplate.
+	ClusterRoles []ClusterRoleTemplate `json:"clusterRoles,omitempty"`
+}
+
+// NamespacedName identifies a generated object by name and, if namespaced,
+// its namespace.
+type NamespacedName struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+const (
+	// ClusterRolesReadyConditionType indicates whether every ClusterRole
+	// described by the ScopeTemplate's spec has been created and matches it.
+	ClusterRolesReadyConditionType = "ClusterRolesReady"
+
+	// BindingsReadyConditionType indicates whether every RoleBinding/
+	// ClusterRoleBinding required by the referencing ScopeInstances exists
+	// and matches the ScopeTemplate's spec.
+	BindingsReadyConditionType = "BindingsReady"
+
+	// DegradedConditionType indicates that the last reconcile failed to
+	// fully converge the generated RBAC to the desired state.
+	DegradedConditionType = "Degraded"
+)
+
+const (
+	// ReasonReconcileSuccess is used when a condition reflects a successful
+	// reconcile.
+	ReasonReconcileSuccess = "ReconcileSuccess"
+
+	// ReasonHashMismatch is used when a generated object's hash label no
+	// longer matches the current spec and is pending cleanup/recreation.
+	ReasonHashMismatch = "HashMismatch"
+
+	// ReasonListError is used when the reconciler failed to list generated
+	// objects.
+	ReasonListError = "ListError"
+
+	// ReasonDuplicateGenerateName is used when more than one ClusterRole is
+	// found for a single GenerateName.
+	ReasonDuplicateGenerateName = "DuplicateGenerateName"
+
+	// ReasonSyncFailed is used when creating or updating a generated
+	// ClusterRole or binding failed for a reason other than a duplicate
+	// GenerateName or a list failure,
+	// e.g. an RBAC error or a quota limit.
+	ReasonSyncFailed = "SyncFailed"
+
+	// ReasonReferencingInstancesMissing is used when no ScopeInstance
+	// references the ScopeTemplate, so no ClusterRoles are generated.
+	ReasonReferencingInstancesMissing = "ReferencingInstancesMissing"
+)
+
+// ScopeTemplateStatus defines the observed state of ScopeTemplate
+type ScopeTemplateStatus struct {
+	// Conditions describes the state of the ScopeTemplate's generated RBAC.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed by the
+	// controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// GeneratedClusterRoles references the ClusterRoles currently generated
+	// from this ScopeTemplate.
+	// +optional
+	GeneratedClusterRoles []NamespacedName `json:"generatedClusterRoles,omitempty"`
+
+	// LastAppliedHash is the hash of the Spec that was last reconciled into
+	// the generated ClusterRoles.
+	// +optional
+	LastAppliedHash string `json:"lastAppliedHash,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="ClusterRolesReady")].status`
+//+kubebuilder:printcolumn:name="Degraded",type=string,JSONPath=`.status.conditions[?(@.type=="Degraded")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ScopeTemplate is the Schema for the scopetemplates API. ScopeTemplate is
+// cluster-scoped: the ClusterRoles it describes, and the subjects granted
+// access to them, span every namespace a referencing ScopeInstance targets.
+type ScopeTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScopeTemplateSpec   `json:"spec,omitempty"`
+	Status ScopeTemplateStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScopeTemplateList contains a list of ScopeTemplate
+type ScopeTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScopeTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScopeTemplate{}, &ScopeTemplateList{})
+}
@@ -0,0 +1,535 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	operatorsv1 "awgreene/scope-operator/api/v1"
+	"awgreene/scope-operator/util"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sapierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ScopeInstanceReconciler reconciles a ScopeInstance object
+type ScopeInstanceReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Recorder emits Kubernetes Events for individual binding failures so
+	// users can see which specific binding failed without grepping logs.
+	Recorder record.EventRecorder
+
+	logger *logrus.Logger
+}
+
+// recordWarning emits a Warning Event against obj if a Recorder is
+// configured.
+func (r *ScopeInstanceReconciler) recordWarning(obj runtime.Object, reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(obj, corev1.EventTypeWarning, reason, message)
+}
+
+const (
+	// bindingGenerateKey tracks the ClusterRole (by GenerateName) that a
+	// generated binding grants.
+	bindingGenerateKey = "operators.coreos.io/generateName"
+)
+
+// bindingTuple identifies a single RoleBinding/ClusterRoleBinding by the
+// ClusterRole it grants and the namespace it grants it in (empty for a
+// ClusterRoleBinding).
+type bindingTuple struct {
+	generateName string
+	namespace    string
+}
+
+//+kubebuilder:rbac:groups=operators.io.operator-framework,resources=scopeinstances,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=operators.io.operator-framework,resources=scopeinstances/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=operators.io.operator-framework,resources=scopeinstances/finalizers,verbs=update
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings;rolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile ensures that the RoleBindings/ClusterRoleBinding required to
+// grant a ScopeInstance's subject access to its ScopeTemplate's ClusterRoles
+// exist, and removes any that no longer belong.
+func (r *ScopeInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	_ = log.FromContext(ctx)
+
+	log.Log.Info("Reconciling ScopeInstance")
+
+	si := &operatorsv1.ScopeInstance{}
+	if err := r.Client.Get(ctx, req.NamespacedName, si); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !si.GetDeletionTimestamp().IsZero() {
+		// si is still visible to r.reconcileBindings' ScopeInstance listing
+		// until the finalizer is removed below, but its DeletionTimestamp
+		// excludes it from the desired Subjects, so this rewrites/deletes
+		// any binding that only existed on si's behalf.
+		st := &operatorsv1.ScopeTemplate{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: si.Spec.ScopeTemplateName}, st)
+		switch {
+		case err == nil:
+			if err := r.reconcileBindings(ctx, st); err != nil {
+				return ctrl.Result{}, fmt.Errorf("clean up generated bindings: %v", err)
+			}
+		case !k8sapierrors.IsNotFound(err):
+			return ctrl.Result{}, fmt.Errorf("get ScopeTemplate %q: %v", si.Spec.ScopeTemplateName, err)
+		}
+
+		controllerutil.RemoveFinalizer(si, scopeCleanupFinalizer)
+		if err := r.Client.Update(ctx, si); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(si, scopeCleanupFinalizer) {
+		controllerutil.AddFinalizer(si, scopeCleanupFinalizer)
+		if err := r.Client.Update(ctx, si); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	st := &operatorsv1.ScopeTemplate{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: si.Spec.ScopeTemplateName}, st); err != nil {
+		return ctrl.Result{}, fmt.Errorf("get ScopeTemplate %q: %v", si.Spec.ScopeTemplateName, err)
+	}
+
+	reconcileErr := r.reconcileBindings(ctx, st)
+
+	if statusErr := r.updateStatus(ctx, si, st, reconcileErr); statusErr != nil {
+		if reconcileErr == nil {
+			return ctrl.Result{}, statusErr
+		}
+		log.Log.Info("Error updating ScopeInstance status", "error", statusErr)
+	}
+
+	if reconcileErr != nil {
+		return ctrl.Result{}, reconcileErr
+	}
+
+	log.Log.Info("No ScopeInstance error")
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileBindings recomputes, for every ClusterRole generated from st, the
+// deduplicated union of Subjects across every ScopeInstance that currently
+// targets a given namespace (or the cluster scope, if unnamespaced), and
+// creates/updates/deletes RoleBindings/ClusterRoleBinding to match.
+func (r *ScopeInstanceReconciler) reconcileBindings(ctx context.Context, st *operatorsv1.ScopeTemplate) error {
+	desired, err := r.desiredSubjects(ctx, st)
+	if err != nil {
+		return withReason(operatorsv1.ReasonListError, fmt.Errorf("compute desired bindings: %v", err))
+	}
+
+	var errs []error
+	for key, subjects := range desired {
+		if len(subjects) == 0 {
+			continue
+		}
+
+		bindingLabels := map[string]string{
+			scopeTemplateUIDKey: string(st.GetUID()),
+			bindingGenerateKey:  key.generateName,
+		}
+
+		roleRef := rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     key.generateName,
+		}
+
+		if key.namespace == "" {
+			err = r.ensureClusterRoleBinding(ctx, st, key.generateName, roleRef, subjects, bindingLabels)
+		} else {
+			err = r.ensureRoleBinding(ctx, st, key.namespace, key.generateName, roleRef, subjects, bindingLabels)
+		}
+		if err != nil {
+			r.recordWarning(st, "BindingSyncFailed", fmt.Sprintf("failed to sync binding for ClusterRole %q in namespace %q: %v", key.generateName, key.namespace, err))
+			errs = append(errs, fmt.Errorf("binding %s/%s: %v", key.namespace, key.generateName, err))
+		}
+	}
+
+	if err := r.deleteStaleBindings(ctx, st, desired); err != nil {
+		errs = append(errs, err)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// desiredSubjects returns, for every (ClusterRole, namespace) tuple
+// st requires, the sorted and deduped union of Subjects from every
+// ScopeInstance that targets it. ScopeInstances that are themselves being
+// deleted are excluded, so that removing one rewrites or deletes the
+// bindings it contributed to.
+func (r *ScopeInstanceReconciler) desiredSubjects(ctx context.Context, st *operatorsv1.ScopeTemplate) (map[bindingTuple][]rbacv1.Subject, error) {
+	scopeInstances := &operatorsv1.ScopeInstanceList{}
+	if err := r.Client.List(ctx, scopeInstances); err != nil {
+		return nil, fmt.Errorf("list ScopeInstances: %v", err)
+	}
+
+	clusterRoles := &rbacv1.ClusterRoleList{}
+	if err := r.Client.List(ctx, clusterRoles, client.MatchingLabels{scopeTemplateUIDKey: string(st.GetUID())}); err != nil {
+		return nil, fmt.Errorf("list ClusterRoles: %v", err)
+	}
+
+	desired := map[bindingTuple][]rbacv1.Subject{}
+	for i := range scopeInstances.Items {
+		si := &scopeInstances.Items[i]
+		if si.Spec.ScopeTemplateName != st.Name || !si.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		namespaces := si.Spec.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{""}
+		}
+
+		for _, cr := range clusterRoles.Items {
+			generateName := cr.Labels[clusterRoleGenerateKey]
+			for _, ns := range namespaces {
+				key := bindingTuple{generateName, ns}
+				desired[key] = append(desired[key], si.Spec.Subjects...)
+			}
+		}
+	}
+
+	for key := range desired {
+		desired[key] = util.DedupeSubjects(desired[key])
+	}
+
+	return desired, nil
+}
+
+// updateStatus looks up the bindings currently generated on si's behalf,
+// records them and reconcileErr as status conditions, and persists the
+// result. Because a binding's Subjects may be shared with other
+// ScopeInstances, si's bindings are identified by the deterministic names
+// its own (ClusterRole, namespace) tuples map to, rather than by a
+// per-instance label.
+func (r *ScopeInstanceReconciler) updateStatus(ctx context.Context, si *operatorsv1.ScopeInstance, st *operatorsv1.ScopeTemplate, reconcileErr error) error {
+	oldStatus := si.Status.DeepCopy()
+
+	clusterRoles := &rbacv1.ClusterRoleList{}
+	listErr := r.Client.List(ctx, clusterRoles, client.MatchingLabels{scopeTemplateUIDKey: string(st.GetUID())})
+
+	var generated []operatorsv1.NamespacedName
+	if listErr == nil {
+		namespaces := si.Spec.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{""}
+		}
+
+		for _, cr := range clusterRoles.Items {
+			generateName := cr.Labels[clusterRoleGenerateKey]
+			for _, ns := range namespaces {
+				name := bindingName(st, generateName, ns)
+
+				if ns == "" {
+					crb := &rbacv1.ClusterRoleBinding{}
+					if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, crb); err == nil {
+						generated = append(generated, operatorsv1.NamespacedName{Name: crb.Name})
+					}
+					continue
+				}
+
+				rb := &rbacv1.RoleBinding{}
+				if err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, rb); err == nil {
+					generated = append(generated, operatorsv1.NamespacedName{Name: rb.Name, Namespace: rb.Namespace})
+				}
+			}
+		}
+	}
+	si.Status.GeneratedBindings = generated
+	si.Status.ObservedGeneration = si.Generation
+
+	switch {
+	case reconcileErr != nil:
+		reason := reconcileErrorReason(reconcileErr)
+		meta.SetStatusCondition(&si.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.BindingsReadyConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: reconcileErr.Error(),
+		})
+		meta.SetStatusCondition(&si.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.DegradedConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: reconcileErr.Error(),
+		})
+	case listErr != nil:
+		meta.SetStatusCondition(&si.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.DegradedConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  operatorsv1.ReasonListError,
+			Message: listErr.Error(),
+		})
+	default:
+		meta.SetStatusCondition(&si.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.BindingsReadyConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  operatorsv1.ReasonReconcileSuccess,
+			Message: "All bindings generated for this ScopeInstance are up to date",
+		})
+		meta.SetStatusCondition(&si.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.DegradedConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  operatorsv1.ReasonReconcileSuccess,
+			Message: "Reconcile succeeded",
+		})
+	}
+
+	// Status().Update bumps resourceVersion and triggers a watch event, so
+	// skip it when nothing actually changed to avoid reconciling forever.
+	if equality.Semantic.DeepEqual(oldStatus, &si.Status) {
+		return nil
+	}
+
+	return r.Client.Status().Update(ctx, si)
+}
+
+// ensureClusterRoleBinding creates or updates the ClusterRoleBinding that
+// grants subjects access to the ClusterRole identified by generateName.
+func (r *ScopeInstanceReconciler) ensureClusterRoleBinding(ctx context.Context, st *operatorsv1.ScopeTemplate, generateName string, roleRef rbacv1.RoleRef, subjects []rbacv1.Subject, bindingLabels map[string]string) error {
+	name := bindingName(st, generateName, "")
+
+	crb := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: st.APIVersion,
+				Kind:       st.Kind,
+				Name:       st.GetName(),
+				UID:        st.GetUID(),
+			}},
+			Labels: bindingLabels,
+		},
+		Subjects: subjects,
+		RoleRef:  roleRef,
+	}
+
+	existing := &rbacv1.ClusterRoleBinding{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: name}, existing)
+	if k8sapierrors.IsNotFound(err) {
+		return r.Client.Create(ctx, crb)
+	}
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(existing.Subjects, crb.Subjects) &&
+		reflect.DeepEqual(existing.RoleRef, crb.RoleRef) &&
+		reflect.DeepEqual(existing.Labels, crb.Labels) {
+		return nil
+	}
+
+	existing.Labels = crb.Labels
+	existing.Subjects = crb.Subjects
+	return r.Client.Update(ctx, existing)
+}
+
+// ensureRoleBinding creates or updates the RoleBinding in namespace that
+// grants subjects access to the ClusterRole identified by generateName.
+func (r *ScopeInstanceReconciler) ensureRoleBinding(ctx context.Context, st *operatorsv1.ScopeTemplate, namespace, generateName string, roleRef rbacv1.RoleRef, subjects []rbacv1.Subject, bindingLabels map[string]string) error {
+	name := bindingName(st, generateName, namespace)
+
+	rb := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: st.APIVersion,
+				Kind:       st.Kind,
+				Name:       st.GetName(),
+				UID:        st.GetUID(),
+			}},
+			Labels: bindingLabels,
+		},
+		Subjects: subjects,
+		RoleRef:  roleRef,
+	}
+
+	existing := &rbacv1.RoleBinding{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, existing)
+	if k8sapierrors.IsNotFound(err) {
+		return r.Client.Create(ctx, rb)
+	}
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(existing.Subjects, rb.Subjects) &&
+		reflect.DeepEqual(existing.RoleRef, rb.RoleRef) &&
+		reflect.DeepEqual(existing.Labels, rb.Labels) {
+		return nil
+	}
+
+	existing.Labels = rb.Labels
+	existing.Subjects = rb.Subjects
+	return r.Client.Update(ctx, existing)
+}
+
+// deleteStaleBindings removes any RoleBinding/ClusterRoleBinding generated
+// from st whose (ClusterRole, namespace) tuple is no longer present in
+// desired with a non-empty Subjects list, e.g. because the ScopeInstance
+// that wanted it was deleted or had its last matching Subject removed.
+func (r *ScopeInstanceReconciler) deleteStaleBindings(ctx context.Context, st *operatorsv1.ScopeTemplate, desired map[bindingTuple][]rbacv1.Subject) error {
+	listOption := client.MatchingLabels{scopeTemplateUIDKey: string(st.GetUID())}
+
+	var errs []error
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := r.Client.List(ctx, roleBindings, listOption); err != nil {
+		errs = append(errs, fmt.Errorf("list RoleBindings: %v", err))
+	} else {
+		for i := range roleBindings.Items {
+			rb := roleBindings.Items[i]
+			if subjects, ok := desired[bindingTuple{rb.Labels[bindingGenerateKey], rb.Namespace}]; ok && len(subjects) > 0 {
+				continue
+			}
+			if err := r.Client.Delete(ctx, &rb); err != nil && !k8sapierrors.IsNotFound(err) {
+				r.recordWarning(&rb, "RoleBindingDeleteFailed", fmt.Sprintf("failed to delete RoleBinding %q: %v", rb.Name, err))
+				errs = append(errs, fmt.Errorf("RoleBinding %s/%s: %v", rb.Namespace, rb.Name, err))
+			}
+		}
+	}
+
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	if err := r.Client.List(ctx, clusterRoleBindings, listOption); err != nil {
+		errs = append(errs, fmt.Errorf("list ClusterRoleBindings: %v", err))
+	} else {
+		for i := range clusterRoleBindings.Items {
+			crb := clusterRoleBindings.Items[i]
+			if subjects, ok := desired[bindingTuple{crb.Labels[bindingGenerateKey], ""}]; ok && len(subjects) > 0 {
+				continue
+			}
+			if err := r.Client.Delete(ctx, &crb); err != nil && !k8sapierrors.IsNotFound(err) {
+				r.recordWarning(&crb, "ClusterRoleBindingDeleteFailed", fmt.Sprintf("failed to delete ClusterRoleBinding %q: %v", crb.Name, err))
+				errs = append(errs, fmt.Errorf("ClusterRoleBinding %s: %v", crb.Name, err))
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// bindingName derives a stable, idempotent name for a binding from the
+// owning ScopeTemplate's UID, the ClusterRole's GenerateName, and the target
+// namespace (empty for a ClusterRoleBinding).
+func bindingName(st *operatorsv1.ScopeTemplate, generateName, namespace string) string {
+	hash := util.HashObject(struct {
+		UID          string
+		GenerateName string
+		Namespace    string
+	}{string(st.GetUID()), generateName, namespace})
+
+	return fmt.Sprintf("%s-%s", generateName, hash[:8])
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScopeInstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	hasScopeTemplateUIDLabel := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := obj.GetLabels()[scopeTemplateUIDKey]
+		return ok
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&operatorsv1.ScopeInstance{}).
+		// Set up a watch for ScopeTemplate to requeue ScopeInstances that
+		// reference it whenever its generated ClusterRoles change.
+		Watches(&source.Kind{Type: &operatorsv1.ScopeTemplate{}}, handler.EnqueueRequestsFromMapFunc(r.mapToScopeInstances)).
+		// Watch the RoleBindings/ClusterRoleBinding we generate so that an
+		// external edit or delete is noticed and reverted without waiting on
+		// the next ScopeTemplate/ScopeInstance event. A binding is owned by
+		// the ScopeTemplate (its Subjects may be shared across several
+		// ScopeInstances), so every ScopeInstance referencing that
+		// ScopeTemplate is requeued.
+		Watches(&source.Kind{Type: &rbacv1.RoleBinding{}}, handler.EnqueueRequestsFromMapFunc(r.mapBindingToScopeInstances), ctrlbuilder.WithPredicates(hasScopeTemplateUIDLabel)).
+		Watches(&source.Kind{Type: &rbacv1.ClusterRoleBinding{}}, handler.EnqueueRequestsFromMapFunc(r.mapBindingToScopeInstances), ctrlbuilder.WithPredicates(hasScopeTemplateUIDLabel)).
+		Complete(r)
+}
+
+// mapBindingToScopeInstances enqueues a reconcile.Request for every
+// ScopeInstance that references the ScopeTemplate recorded in obj's
+// OwnerReferences.
+func (r *ScopeInstanceReconciler) mapBindingToScopeInstances(obj client.Object) []reconcile.Request {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind != "ScopeTemplate" {
+			continue
+		}
+		return r.requestsForScopeTemplate(owner.Name)
+	}
+	return nil
+}
+
+func (r *ScopeInstanceReconciler) mapToScopeInstances(obj client.Object) []reconcile.Request {
+	st, ok := obj.(*operatorsv1.ScopeTemplate)
+	if !ok {
+		return nil
+	}
+
+	return r.requestsForScopeTemplate(st.GetName())
+}
+
+// requestsForScopeTemplate enqueues a reconcile.Request for every
+// ScopeInstance that references the ScopeTemplate named templateName.
+func (r *ScopeInstanceReconciler) requestsForScopeTemplate(templateName string) (requests []reconcile.Request) {
+	ctx := context.TODO()
+	scopeInstances := &operatorsv1.ScopeInstanceList{}
+	if err := r.Client.List(ctx, scopeInstances); err != nil {
+		return nil
+	}
+
+	for _, si := range scopeInstances.Items {
+		if si.Spec.ScopeTemplateName == templateName {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: si.GetName(), Namespace: si.GetNamespace()},
+			})
+		}
+	}
+
+	return requests
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	operatorsv1 "awgreene/scope-operator/api/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// getGeneratedRoleBinding fetches the RoleBinding generated from st for
+// generateName in namespace, by the same deterministic name bindingName
+// derives for the reconciler itself.
+func getGeneratedRoleBinding(st *operatorsv1.ScopeTemplate, generateName, namespace string, out *rbacv1.RoleBinding) error {
+	return k8sClient.Get(ctx, types.NamespacedName{Name: bindingName(st, generateName, namespace), Namespace: namespace}, out)
+}
+
+var _ = Describe("ScopeInstanceReconciler", func() {
+	var (
+		namespace *corev1.Namespace
+		st        *operatorsv1.ScopeTemplate
+	)
+
+	BeforeEach(func() {
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "scope-instance-"}}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+
+		st = &operatorsv1.ScopeTemplate{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "template-"},
+			Spec: operatorsv1.ScopeTemplateSpec{
+				ClusterRoles: []operatorsv1.ClusterRoleTemplate{{
+					GenerateName: "generated-role",
+					Rules:        []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, st)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, namespace)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, st)).To(Succeed())
+	})
+
+	It("creates, updates, and deletes the RoleBinding granting its Subjects access", func() {
+		si := &operatorsv1.ScopeInstance{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "instance-", Namespace: namespace.Name},
+			Spec: operatorsv1.ScopeInstanceSpec{
+				ScopeTemplateName: st.Name,
+				Namespaces:        []string{namespace.Name},
+				Subjects:          []rbacv1.Subject{adminSubject},
+			},
+		}
+		Expect(k8sClient.Create(ctx, si)).To(Succeed())
+
+		// add: referencing st generates st's ClusterRole and a RoleBinding to it.
+		rb := &rbacv1.RoleBinding{}
+		Eventually(func() ([]rbacv1.Subject, error) {
+			if err := getGeneratedRoleBinding(st, "generated-role", namespace.Name, rb); err != nil {
+				return nil, err
+			}
+			return rb.Subjects, nil
+		}, envtestTimeout, envtestInterval).Should(Equal([]rbacv1.Subject{adminSubject}))
+
+		// update: adding a Subject is reflected onto the existing RoleBinding.
+		Eventually(func() error {
+			latest := &operatorsv1.ScopeInstance{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: si.Name, Namespace: si.Namespace}, latest); err != nil {
+				return err
+			}
+			latest.Spec.Subjects = []rbacv1.Subject{adminSubject, viewerSubject}
+			return k8sClient.Update(ctx, latest)
+		}, envtestTimeout, envtestInterval).Should(Succeed())
+
+		Eventually(func() ([]rbacv1.Subject, error) {
+			if err := getGeneratedRoleBinding(st, "generated-role", namespace.Name, rb); err != nil {
+				return nil, err
+			}
+			return rb.Subjects, nil
+		}, envtestTimeout, envtestInterval).Should(Equal([]rbacv1.Subject{adminSubject, viewerSubject}))
+
+		// delete: removing si cleans up the RoleBinding it was the sole contributor to.
+		Expect(k8sClient.Delete(ctx, si)).To(Succeed())
+		Eventually(func() error {
+			return getGeneratedRoleBinding(st, "generated-role", namespace.Name, rb)
+		}, envtestTimeout, envtestInterval).ShouldNot(Succeed())
+	})
+})
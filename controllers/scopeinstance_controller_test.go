@@ -0,0 +1,278 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	operatorsv1 "awgreene/scope-operator/api/v1"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, operatorsv1.AddToScheme(scheme))
+	return scheme
+}
+
+func newScopeTemplate(name string) *operatorsv1.ScopeTemplate {
+	return &operatorsv1.ScopeTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "-uid")},
+	}
+}
+
+var adminSubject = rbacv1.Subject{Kind: rbacv1.UserKind, Name: "admin", APIGroup: rbacv1.GroupName}
+var viewerSubject = rbacv1.Subject{Kind: rbacv1.UserKind, Name: "viewer", APIGroup: rbacv1.GroupName}
+
+func TestEnsureClusterRoleBinding(t *testing.T) {
+	st := newScopeTemplate("template-a")
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "generated-role"}
+	labels := map[string]string{scopeTemplateUIDKey: string(st.GetUID()), bindingGenerateKey: "generated-role"}
+	name := bindingName(st, "generated-role", "")
+
+	t.Run("create: no existing ClusterRoleBinding", func(t *testing.T) {
+		r := &ScopeInstanceReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).Build()}
+
+		require.NoError(t, r.ensureClusterRoleBinding(context.Background(), st, "generated-role", roleRef, []rbacv1.Subject{adminSubject}, labels))
+
+		crb := &rbacv1.ClusterRoleBinding{}
+		require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: name}, crb))
+		require.Equal(t, []rbacv1.Subject{adminSubject}, crb.Subjects)
+		require.Equal(t, roleRef, crb.RoleRef)
+	})
+
+	t.Run("update: existing ClusterRoleBinding has stale subjects", func(t *testing.T) {
+		existing := &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Subjects:   []rbacv1.Subject{viewerSubject},
+			RoleRef:    roleRef,
+		}
+		r := &ScopeInstanceReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(existing).Build()}
+
+		require.NoError(t, r.ensureClusterRoleBinding(context.Background(), st, "generated-role", roleRef, []rbacv1.Subject{adminSubject, viewerSubject}, labels))
+
+		crb := &rbacv1.ClusterRoleBinding{}
+		require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: name}, crb))
+		require.Equal(t, []rbacv1.Subject{adminSubject, viewerSubject}, crb.Subjects)
+	})
+
+	t.Run("no-op: existing ClusterRoleBinding already matches", func(t *testing.T) {
+		existing := &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, ResourceVersion: "1"},
+			Subjects:   []rbacv1.Subject{adminSubject},
+			RoleRef:    roleRef,
+		}
+		r := &ScopeInstanceReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(existing).Build()}
+
+		require.NoError(t, r.ensureClusterRoleBinding(context.Background(), st, "generated-role", roleRef, []rbacv1.Subject{adminSubject}, labels))
+
+		crb := &rbacv1.ClusterRoleBinding{}
+		require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: name}, crb))
+		require.Equal(t, "1", crb.ResourceVersion)
+	})
+}
+
+func TestEnsureRoleBinding(t *testing.T) {
+	st := newScopeTemplate("template-a")
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "generated-role"}
+	labels := map[string]string{scopeTemplateUIDKey: string(st.GetUID()), bindingGenerateKey: "generated-role"}
+	name := bindingName(st, "generated-role", "team-a")
+
+	t.Run("create: no existing RoleBinding", func(t *testing.T) {
+		r := &ScopeInstanceReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).Build()}
+
+		require.NoError(t, r.ensureRoleBinding(context.Background(), st, "team-a", "generated-role", roleRef, []rbacv1.Subject{adminSubject}, labels))
+
+		rb := &rbacv1.RoleBinding{}
+		require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "team-a"}, rb))
+		require.Equal(t, []rbacv1.Subject{adminSubject}, rb.Subjects)
+	})
+
+	t.Run("update: existing RoleBinding has stale subjects", func(t *testing.T) {
+		existing := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a", Labels: labels},
+			Subjects:   []rbacv1.Subject{viewerSubject},
+			RoleRef:    roleRef,
+		}
+		r := &ScopeInstanceReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(existing).Build()}
+
+		require.NoError(t, r.ensureRoleBinding(context.Background(), st, "team-a", "generated-role", roleRef, []rbacv1.Subject{adminSubject}, labels))
+
+		rb := &rbacv1.RoleBinding{}
+		require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "team-a"}, rb))
+		require.Equal(t, []rbacv1.Subject{adminSubject}, rb.Subjects)
+	})
+}
+
+func TestDeleteStaleBindings(t *testing.T) {
+	st := newScopeTemplate("template-a")
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "generated-role"}
+	labels := map[string]string{scopeTemplateUIDKey: string(st.GetUID()), bindingGenerateKey: "generated-role"}
+
+	staleRB := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: bindingName(st, "generated-role", "team-a"), Namespace: "team-a", Labels: labels},
+		RoleRef:    roleRef,
+	}
+	keptRB := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: bindingName(st, "generated-role", "team-b"), Namespace: "team-b", Labels: labels},
+		RoleRef:    roleRef,
+	}
+	staleCRB := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: bindingName(st, "generated-role", ""), Labels: labels},
+		RoleRef:    roleRef,
+	}
+
+	r := &ScopeInstanceReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(staleRB, keptRB, staleCRB).Build()}
+
+	// Only team-b still has Subjects wanting the binding; the ClusterRoleBinding
+	// tuple (namespace "") is entirely absent from desired, so it's stale too.
+	desired := map[bindingTuple][]rbacv1.Subject{
+		{generateName: "generated-role", namespace: "team-b"}: {adminSubject},
+	}
+
+	require.NoError(t, r.deleteStaleBindings(context.Background(), st, desired))
+
+	err := r.Client.Get(context.Background(), types.NamespacedName{Name: staleRB.Name, Namespace: "team-a"}, &rbacv1.RoleBinding{})
+	require.True(t, isNotFound(err), "expected stale RoleBinding to be deleted, got err=%v", err)
+
+	require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: keptRB.Name, Namespace: "team-b"}, &rbacv1.RoleBinding{}))
+
+	err = r.Client.Get(context.Background(), types.NamespacedName{Name: staleCRB.Name}, &rbacv1.ClusterRoleBinding{})
+	require.True(t, isNotFound(err), "expected stale ClusterRoleBinding to be deleted, got err=%v", err)
+}
+
+func TestReconcileBindings(t *testing.T) {
+	ctx := context.Background()
+	st := newScopeTemplate("template-a")
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "generated-role",
+			Labels: map[string]string{scopeTemplateUIDKey: string(st.GetUID()), clusterRoleGenerateKey: "generated-role"},
+		},
+	}
+	instanceA := &operatorsv1.ScopeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-a"},
+		Spec: operatorsv1.ScopeInstanceSpec{
+			ScopeTemplateName: st.Name,
+			Namespaces:        []string{"team-a"},
+			Subjects:          []rbacv1.Subject{adminSubject},
+		},
+	}
+
+	r := &ScopeInstanceReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(st, clusterRole, instanceA).Build()}
+
+	// add: reconciling with a single ScopeInstance creates its RoleBinding.
+	require.NoError(t, r.reconcileBindings(ctx, st))
+
+	rbName := bindingName(st, "generated-role", "team-a")
+	rb := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: rbName, Namespace: "team-a"}, rb))
+	require.Equal(t, []rbacv1.Subject{adminSubject}, rb.Subjects)
+
+	// update: a second ScopeInstance targeting the same namespace contributes
+	// its Subject to the same deduplicated RoleBinding.
+	instanceB := &operatorsv1.ScopeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-b"},
+		Spec: operatorsv1.ScopeInstanceSpec{
+			ScopeTemplateName: st.Name,
+			Namespaces:        []string{"team-a"},
+			Subjects:          []rbacv1.Subject{viewerSubject},
+		},
+	}
+	require.NoError(t, r.Client.Create(ctx, instanceB))
+	require.NoError(t, r.reconcileBindings(ctx, st))
+
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: rbName, Namespace: "team-a"}, rb))
+	require.Equal(t, []rbacv1.Subject{adminSubject, viewerSubject}, rb.Subjects)
+
+	// delete: removing both ScopeInstances leaves no desired Subjects, so the
+	// RoleBinding is cleaned up.
+	require.NoError(t, r.Client.Delete(ctx, instanceA))
+	require.NoError(t, r.Client.Delete(ctx, instanceB))
+	require.NoError(t, r.reconcileBindings(ctx, st))
+
+	err := r.Client.Get(ctx, types.NamespacedName{Name: rbName, Namespace: "team-a"}, rb)
+	require.True(t, isNotFound(err), "expected RoleBinding to be deleted once no ScopeInstance references it, got err=%v", err)
+}
+
+func isNotFound(err error) bool {
+	return client.IgnoreNotFound(err) == nil && err != nil
+}
+
+// TestScopeInstanceReconcile_Deletion drives Reconcile's DeletionTimestamp
+// branch directly to confirm the finalizer is removed and a binding shared
+// with another ScopeInstance is rewritten down to the remaining Subject set,
+// rather than deleted outright.
+func TestScopeInstanceReconcile_Deletion(t *testing.T) {
+	ctx := context.Background()
+	st := newScopeTemplate("template-a")
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "generated-role",
+			Labels: map[string]string{scopeTemplateUIDKey: string(st.GetUID()), clusterRoleGenerateKey: "generated-role"},
+		},
+	}
+	instanceA := &operatorsv1.ScopeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-a", Finalizers: []string{scopeCleanupFinalizer}},
+		Spec: operatorsv1.ScopeInstanceSpec{
+			ScopeTemplateName: st.Name,
+			Namespaces:        []string{"team-a"},
+			Subjects:          []rbacv1.Subject{adminSubject},
+		},
+	}
+	instanceB := &operatorsv1.ScopeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-b"},
+		Spec: operatorsv1.ScopeInstanceSpec{
+			ScopeTemplateName: st.Name,
+			Namespaces:        []string{"team-a"},
+			Subjects:          []rbacv1.Subject{viewerSubject},
+		},
+	}
+
+	r := &ScopeInstanceReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(st, clusterRole, instanceA, instanceB).Build()}
+
+	// Both ScopeInstances contribute to the same RoleBinding before either is deleted.
+	require.NoError(t, r.reconcileBindings(ctx, st))
+	rbName := bindingName(st, "generated-role", "team-a")
+	rb := &rbacv1.RoleBinding{}
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: rbName, Namespace: "team-a"}, rb))
+	require.Equal(t, []rbacv1.Subject{adminSubject, viewerSubject}, rb.Subjects)
+
+	require.NoError(t, r.Client.Delete(ctx, instanceA))
+
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceA.Name}})
+	require.NoError(t, err)
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: instanceA.Name}, &operatorsv1.ScopeInstance{})
+	require.True(t, isNotFound(err), "expected ScopeInstance to be gone once its finalizer is removed, got err=%v", err)
+
+	// The RoleBinding survives, shrunk to instance-b's Subject only.
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: rbName, Namespace: "team-a"}, rb))
+	require.Equal(t, []rbacv1.Subject{viewerSubject}, rb.Subjects)
+}
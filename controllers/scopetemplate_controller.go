@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 
@@ -25,17 +26,25 @@ import (
 	"awgreene/scope-operator/util"
 
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8sapierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
@@ -45,9 +54,23 @@ type ScopeTemplateReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 
+	// Recorder emits Kubernetes Events for individual ClusterRole/binding
+	// failures so users can see which generated object failed without
+	// grepping logs.
+	Recorder record.EventRecorder
+
 	logger *logrus.Logger
 }
 
+// recordWarning emits a Warning Event against obj if a Recorder is
+// configured.
+func (r *ScopeTemplateReconciler) recordWarning(obj runtime.Object, reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(obj, corev1.EventTypeWarning, reason, message)
+}
+
 const (
 	// UID keys are used to track "owners" of bindings we create.
 	scopeTemplateUIDKey = "operators.coreos.io/scopeTemplateUID"
@@ -57,11 +80,18 @@ const (
 
 	// generateNames are used to track each binding we create for a single scopeTemplate
 	clusterRoleGenerateKey = "operators.coreos.io/generateName"
+
+	// scopeCleanupFinalizer is added to both ScopeTemplates and ScopeInstances
+	// to ensure their generated ClusterRoles and bindings are removed, even
+	// from namespaces the owner cannot garbage-collect via OwnerReferences,
+	// before the owner itself is deleted.
+	scopeCleanupFinalizer = "operators.coreos.io/scope-cleanup"
 )
 
 //+kubebuilder:rbac:groups=operators.io.operator-framework,resources=scopetemplates,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=operators.io.operator-framework,resources=scopetemplates/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operators.io.operator-framework,resources=scopetemplates/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -80,14 +110,55 @@ func (r *ScopeTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	// get the scope template
 	st := &operatorsv1.ScopeTemplate{}
 	if err := r.Client.Get(ctx, req.NamespacedName, st); err != nil {
-		return ctrl.Result{}, err
+		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
 	log.Log.Info("Getting ScopeTemplate", "name", st.Name)
 
+	if !st.GetDeletionTimestamp().IsZero() {
+		if err := r.cleanupGeneratedRBAC(ctx, st.GetUID()); err != nil {
+			return ctrl.Result{}, fmt.Errorf("clean up generated RBAC: %v", err)
+		}
+
+		controllerutil.RemoveFinalizer(st, scopeCleanupFinalizer)
+		if err := r.Client.Update(ctx, st); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(st, scopeCleanupFinalizer) {
+		controllerutil.AddFinalizer(st, scopeCleanupFinalizer)
+		if err := r.Client.Update(ctx, st); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	reconcileErr := r.reconcileClusterRoles(ctx, st)
+
+	if statusErr := r.updateStatus(ctx, st, reconcileErr); statusErr != nil {
+		if reconcileErr == nil {
+			return ctrl.Result{}, statusErr
+		}
+		log.Log.Info("Error updating ScopeTemplate status", "error", statusErr)
+	}
+
+	if reconcileErr != nil {
+		return ctrl.Result{}, reconcileErr
+	}
+
+	log.Log.Info("No ScopeTemplate error")
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileClusterRoles creates/updates every ClusterRole described by st's
+// spec and garbage-collects any that no longer match it.
+func (r *ScopeTemplateReconciler) reconcileClusterRoles(ctx context.Context, st *operatorsv1.ScopeTemplate) error {
 	// create ClusterRoles based on the ScopeTemplate
 	if err := r.ensureClusterRoles(ctx, st); err != nil {
-		return ctrl.Result{}, fmt.Errorf("create ClusterRoles: %v", err)
+		return fmt.Errorf("create ClusterRoles: %w", err)
 	}
 
 	listOption := client.MatchingLabels{
@@ -96,7 +167,7 @@ func (r *ScopeTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	requirement, err := labels.NewRequirement(scopeTemplateHashKey, selection.NotEquals, []string{util.HashObject(st.Spec)})
 	if err != nil {
-		return ctrl.Result{}, err
+		return err
 	}
 
 	listOptions := &client.ListOptions{
@@ -105,134 +176,325 @@ func (r *ScopeTemplateReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	if err := r.deleteClusterRolesNEW(ctx, listOption, listOptions); err != nil {
 		log.Log.Info("Error in deleting Role Bindings", "error", err)
-		return ctrl.Result{}, err
+		// This deletes ClusterRoles whose hash label no longer matches the
+		// current spec, so a failure here is a hash mismatch pending cleanup.
+		return withReason(operatorsv1.ReasonHashMismatch, err)
 	}
 
-	log.Log.Info("No ScopeTemplate error")
+	return nil
+}
 
-	return ctrl.Result{}, nil
+// cleanupGeneratedRBAC deletes every ClusterRole, ClusterRoleBinding, and
+// RoleBinding (in any namespace) labeled with templateUID, so that no
+// generated RBAC outlives the ScopeTemplate that produced it.
+func (r *ScopeTemplateReconciler) cleanupGeneratedRBAC(ctx context.Context, templateUID types.UID) error {
+	listOption := client.MatchingLabels{scopeTemplateUIDKey: string(templateUID)}
+
+	var errs []error
+
+	if err := r.deleteClusterRolesNEW(ctx, listOption); err != nil {
+		errs = append(errs, err)
+	}
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := r.Client.List(ctx, roleBindings, listOption); err != nil {
+		errs = append(errs, fmt.Errorf("list RoleBindings: %v", err))
+	} else {
+		for i := range roleBindings.Items {
+			rb := roleBindings.Items[i]
+			if err := r.Client.Delete(ctx, &rb); err != nil && !k8sapierrors.IsNotFound(err) {
+				r.recordWarning(&rb, "RoleBindingDeleteFailed", fmt.Sprintf("failed to delete RoleBinding %q: %v", rb.Name, err))
+				errs = append(errs, fmt.Errorf("RoleBinding %s/%s: %v", rb.Namespace, rb.Name, err))
+			}
+		}
+	}
+
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	if err := r.Client.List(ctx, clusterRoleBindings, listOption); err != nil {
+		errs = append(errs, fmt.Errorf("list ClusterRoleBindings: %v", err))
+	} else {
+		for i := range clusterRoleBindings.Items {
+			crb := clusterRoleBindings.Items[i]
+			if err := r.Client.Delete(ctx, &crb); err != nil && !k8sapierrors.IsNotFound(err) {
+				r.recordWarning(&crb, "ClusterRoleBindingDeleteFailed", fmt.Sprintf("failed to delete ClusterRoleBinding %q: %v", crb.Name, err))
+				errs = append(errs, fmt.Errorf("ClusterRoleBinding %s: %v", crb.Name, err))
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// updateStatus lists the ClusterRoles currently generated from st, records
+// them and reconcileErr as status conditions, and persists the result.
+func (r *ScopeTemplateReconciler) updateStatus(ctx context.Context, st *operatorsv1.ScopeTemplate, reconcileErr error) error {
+	oldStatus := st.Status.DeepCopy()
+
+	clusterRoles := &rbacv1.ClusterRoleList{}
+	listErr := r.Client.List(ctx, clusterRoles, client.MatchingLabels{scopeTemplateUIDKey: string(st.GetUID())})
+
+	generated := make([]operatorsv1.NamespacedName, 0, len(clusterRoles.Items))
+	for _, cr := range clusterRoles.Items {
+		generated = append(generated, operatorsv1.NamespacedName{Name: cr.Name})
+	}
+	st.Status.GeneratedClusterRoles = generated
+	st.Status.ObservedGeneration = st.Generation
+	if reconcileErr == nil {
+		st.Status.LastAppliedHash = util.HashObject(st.Spec)
+	}
+
+	switch {
+	case reconcileErr != nil:
+		reason := reconcileErrorReason(reconcileErr)
+		meta.SetStatusCondition(&st.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.ClusterRolesReadyConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: reconcileErr.Error(),
+		})
+		meta.SetStatusCondition(&st.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.DegradedConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  reason,
+			Message: reconcileErr.Error(),
+		})
+	case listErr != nil:
+		meta.SetStatusCondition(&st.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.DegradedConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  operatorsv1.ReasonListError,
+			Message: listErr.Error(),
+		})
+	case len(generated) == 0 && len(st.Spec.ClusterRoles) > 0:
+		meta.SetStatusCondition(&st.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.ClusterRolesReadyConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  operatorsv1.ReasonReferencingInstancesMissing,
+			Message: "No ScopeInstance references this ScopeTemplate",
+		})
+		meta.SetStatusCondition(&st.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.DegradedConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  operatorsv1.ReasonReferencingInstancesMissing,
+			Message: "No ScopeInstance references this ScopeTemplate",
+		})
+	default:
+		meta.SetStatusCondition(&st.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.ClusterRolesReadyConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  operatorsv1.ReasonReconcileSuccess,
+			Message: "All ClusterRoles generated from this ScopeTemplate are up to date",
+		})
+		meta.SetStatusCondition(&st.Status.Conditions, metav1.Condition{
+			Type:    operatorsv1.DegradedConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  operatorsv1.ReasonReconcileSuccess,
+			Message: "Reconcile succeeded",
+		})
+	}
+
+	// Status().Update bumps resourceVersion and triggers a watch event, so
+	// skip it when nothing actually changed to avoid reconciling forever.
+	if equality.Semantic.DeepEqual(oldStatus, &st.Status) {
+		return nil
+	}
+
+	return r.Client.Status().Update(ctx, st)
+}
+
+// reasonError pairs an error with the status condition reason that best
+// describes it, so reconcileErrorReason doesn't have to infer the reason
+// from the error's text.
+type reasonError struct {
+	reason string
+	err    error
+}
+
+func (e *reasonError) Error() string { return e.err.Error() }
+func (e *reasonError) Unwrap() error { return e.err }
+
+// withReason wraps err, if non-nil, with the status condition reason that
+// produced it.
+func withReason(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &reasonError{reason: reason, err: err}
+}
+
+// reasonForErrs returns the reason carried by the first err in errs that was
+// wrapped with withReason, or ReasonSyncFailed if none were.
+func reasonForErrs(errs []error) string {
+	for _, err := range errs {
+		var re *reasonError
+		if errors.As(err, &re) {
+			return re.reason
+		}
+	}
+	return operatorsv1.ReasonSyncFailed
+}
+
+// reconcileErrorReason maps a reconcile error to the status condition reason
+// that best describes it.
+func reconcileErrorReason(err error) string {
+	var re *reasonError
+	if errors.As(err, &re) {
+		return re.reason
+	}
+	return operatorsv1.ReasonSyncFailed
 }
 
 func (r *ScopeTemplateReconciler) deleteClusterRolesNEW(ctx context.Context, listOptions ...client.ListOption) error {
 	clusterRoles := &rbacv1.ClusterRoleList{}
 	if err := r.Client.List(ctx, clusterRoles, listOptions...); err != nil {
-		// TODO: Aggregate errors
-		return err
+		return fmt.Errorf("list ClusterRoles: %v", err)
 	}
 
-	for _, crb := range clusterRoles.Items {
-		// TODO: Aggregate errors
-		if err := r.Client.Delete(ctx, &crb); err != nil && !k8sapierrors.IsNotFound(err) {
-			return err
+	var errs []error
+	for i := range clusterRoles.Items {
+		cr := clusterRoles.Items[i]
+		if err := r.Client.Delete(ctx, &cr); err != nil && !k8sapierrors.IsNotFound(err) {
+			r.recordWarning(&cr, "ClusterRoleDeleteFailed", fmt.Sprintf("failed to delete ClusterRole %q: %v", cr.Name, err))
+			errs = append(errs, fmt.Errorf("ClusterRole %s: %v", cr.Name, err))
 		}
 	}
-	return nil
+	return utilerrors.NewAggregate(errs)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ScopeTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	hasScopeTemplateUIDLabel := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := obj.GetLabels()[scopeTemplateUIDKey]
+		return ok
+	})
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&operatorsv1.ScopeTemplate{}).
 		// Set up a watch for ScopeInstance to handle requeuing of requests for ScopeTemplate
 		Watches(&source.Kind{Type: &operatorsv1.ScopeInstance{}}, handler.EnqueueRequestsFromMapFunc(r.mapToScopeTemplate)).
+		// Watch the ClusterRoles we generate so that an external edit or delete
+		// is noticed and reverted without waiting on the next ScopeTemplate/
+		// ScopeInstance event.
+		Watches(&source.Kind{Type: &rbacv1.ClusterRole{}}, handler.EnqueueRequestsFromMapFunc(r.mapToOwningScopeTemplate), ctrlbuilder.WithPredicates(hasScopeTemplateUIDLabel)).
 		Complete(r)
 }
 
-func (r *ScopeTemplateReconciler) mapToScopeTemplate(obj client.Object) (requests []reconcile.Request) {
-	if obj == nil || obj.GetName() == "" {
-		return
-	}
-
-	ctx := context.TODO()
-	//(todo): Check if obj can be converted into a scope instance.
-	scopeInstance := &operatorsv1.ScopeInstance{}
-	if err := r.Client.Get(ctx, types.NamespacedName{Name: obj.GetName()}, scopeInstance); err != nil {
-		return nil
+// mapToOwningScopeTemplate enqueues a reconcile.Request for the ScopeTemplate
+// that owns obj, as recorded in obj's OwnerReferences.
+func (r *ScopeTemplateReconciler) mapToOwningScopeTemplate(obj client.Object) []reconcile.Request {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind != "ScopeTemplate" {
+			continue
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: owner.Name}}}
 	}
+	return nil
+}
 
-	// Exit early if scopeInstance doesn't reference a scopeTemplate
-	if scopeInstance.Spec.ScopeTemplateName == "" {
+// mapToScopeTemplate enqueues a reconcile.Request for the ScopeTemplate
+// referenced by the ScopeInstance obj. obj is read directly rather than
+// re-fetched from the API server so that delete events, where the object no
+// longer exists to Get, are handled the same as create/update. ScopeTemplate
+// is cluster-scoped, so the request carries only its Name.
+//
+// This is called separately with the old and new object on update events
+// (see EnqueueRequestsFromMapFunc), so a ScopeInstance moved from one
+// ScopeTemplate to another requeues both: the old one stops generating
+// bindings on its behalf, and the new one starts. That makes a separate
+// lookup of "every ScopeTemplate whose generated ClusterRoles are labeled
+// with the old instance" both unnecessary and stale: since the
+// Subjects/Groups rework, generated ClusterRoles and bindings are labeled
+// with the owning ScopeTemplate's UID, not any ScopeInstance's, so no such
+// label exists to query on. The old/new fan-out above is what actually
+// carries the "old ScopeTemplate" information for re-parenting.
+func (r *ScopeTemplateReconciler) mapToScopeTemplate(obj client.Object) []reconcile.Request {
+	scopeInstance, ok := obj.(*operatorsv1.ScopeInstance)
+	if !ok || scopeInstance.Spec.ScopeTemplateName == "" {
 		return nil
 	}
 
-	// enqueue requests for ScopeTemplate based on Name and Namespace
-	request := reconcile.Request{
-		NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: scopeInstance.Spec.ScopeTemplateName},
-	}
-
-	requests = append(requests, request)
-
-	return requests
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: scopeInstance.Spec.ScopeTemplateName},
+	}}
 }
 
 func (r *ScopeTemplateReconciler) ensureClusterRoles(ctx context.Context, st *operatorsv1.ScopeTemplate) error {
 	scopeinstances := operatorsv1.ScopeInstanceList{}
 
 	if err := r.Client.List(ctx, &scopeinstances, client.InNamespace(st.Namespace)); err != nil {
-		return fmt.Errorf("list scope instances: %v", err)
+		return withReason(operatorsv1.ReasonListError, fmt.Errorf("list scope instances: %v", err))
 	}
 
 	// Create all the ClusterRoles
+	var errs []error
 	for i := range scopeinstances.Items {
 		sInstance := scopeinstances.Items[i]
-		if sInstance.Spec.ScopeTemplateName == st.Name {
-			log.Log.Info("ScopeInstance found that references ScopeTemplate", "name", st.Name)
-			for _, cr := range st.Spec.ClusterRoles {
-				clusterRole := &rbacv1.ClusterRole{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: cr.GenerateName,
-						OwnerReferences: []metav1.OwnerReference{{
-							APIVersion: st.APIVersion,
-							Kind:       st.Kind,
-							Name:       st.GetObjectMeta().GetName(),
-							UID:        st.GetObjectMeta().GetUID(),
-						}},
-						Labels: map[string]string{
-							scopeTemplateUIDKey:    string(st.GetUID()),
-							scopeTemplateHashKey:   util.HashObject(st.Spec),
-							clusterRoleGenerateKey: cr.GenerateName,
-						},
-					},
-					Rules: cr.Rules,
-				}
-
-				crbList := &rbacv1.ClusterRoleList{}
-				if err := r.Client.List(ctx, crbList, client.MatchingLabels{
-					scopeTemplateUIDKey:    string(st.GetUID()),
-					clusterRoleGenerateKey: cr.GenerateName,
-				}); err != nil {
-					return err
-				}
-
-				if len(crbList.Items) > 1 {
-					return fmt.Errorf("more than one ClusterRole found %s", cr.GenerateName)
-				}
-
-				// GenerateName is immutable, so create the object if it has changed
-				if len(crbList.Items) == 0 {
-					if err := r.Client.Create(ctx, clusterRole); err != nil {
-						return err
-					}
-					continue
-				}
-
-				existingCRB := &crbList.Items[0]
-
-				if util.IsOwnedByLabel(existingCRB.DeepCopy(), st) &&
-					reflect.DeepEqual(existingCRB.Rules, clusterRole.Rules) &&
-					reflect.DeepEqual(existingCRB.Labels, clusterRole.Labels) {
-					r.logger.Info("Existing ClusterRoleBinding does not need to be updated")
-					return nil
-				}
-				existingCRB.Labels = clusterRole.Labels
-				existingCRB.OwnerReferences = clusterRole.OwnerReferences
-				existingCRB.Rules = clusterRole.Rules
-
-				if err := r.Client.Update(ctx, existingCRB); err != nil {
-					return err
-				}
+		if sInstance.Spec.ScopeTemplateName != st.Name {
+			continue
+		}
+
+		log.Log.Info("ScopeInstance found that references ScopeTemplate", "name", st.Name)
+		for _, cr := range st.Spec.ClusterRoles {
+			if err := r.ensureClusterRole(ctx, st, cr); err != nil {
+				r.recordWarning(st, "ClusterRoleSyncFailed", fmt.Sprintf("failed to sync ClusterRole %q: %v", cr.GenerateName, err))
+				errs = append(errs, fmt.Errorf("ClusterRole %s: %w", cr.GenerateName, err))
 			}
 		}
 	}
-	return nil
+	if len(errs) == 0 {
+		return nil
+	}
+	return withReason(reasonForErrs(errs), utilerrors.NewAggregate(errs))
+}
+
+// ensureClusterRole creates or updates the single ClusterRole described by cr.
+func (r *ScopeTemplateReconciler) ensureClusterRole(ctx context.Context, st *operatorsv1.ScopeTemplate, cr operatorsv1.ClusterRoleTemplate) error {
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: cr.GenerateName,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: st.APIVersion,
+				Kind:       st.Kind,
+				Name:       st.GetObjectMeta().GetName(),
+				UID:        st.GetObjectMeta().GetUID(),
+			}},
+			Labels: map[string]string{
+				scopeTemplateUIDKey:    string(st.GetUID()),
+				scopeTemplateHashKey:   util.HashObject(st.Spec),
+				clusterRoleGenerateKey: cr.GenerateName,
+			},
+		},
+		Rules: cr.Rules,
+	}
+
+	crbList := &rbacv1.ClusterRoleList{}
+	if err := r.Client.List(ctx, crbList, client.MatchingLabels{
+		scopeTemplateUIDKey:    string(st.GetUID()),
+		clusterRoleGenerateKey: cr.GenerateName,
+	}); err != nil {
+		return withReason(operatorsv1.ReasonListError, err)
+	}
+
+	if len(crbList.Items) > 1 {
+		return withReason(operatorsv1.ReasonDuplicateGenerateName, fmt.Errorf("more than one ClusterRole found %s", cr.GenerateName))
+	}
+
+	// GenerateName is immutable, so create the object if it has changed
+	if len(crbList.Items) == 0 {
+		return withReason(operatorsv1.ReasonSyncFailed, r.Client.Create(ctx, clusterRole))
+	}
+
+	existingCRB := &crbList.Items[0]
+
+	if util.IsOwnedByLabel(existingCRB.DeepCopy(), st) &&
+		reflect.DeepEqual(existingCRB.Rules, clusterRole.Rules) &&
+		reflect.DeepEqual(existingCRB.Labels, clusterRole.Labels) {
+		log.Log.Info("Existing ClusterRole does not need to be updated", "name", cr.GenerateName)
+		return nil
+	}
+	existingCRB.Labels = clusterRole.Labels
+	existingCRB.OwnerReferences = clusterRole.OwnerReferences
+	existingCRB.Rules = clusterRole.Rules
+
+	return withReason(operatorsv1.ReasonSyncFailed, r.Client.Update(ctx, existingCRB))
 }
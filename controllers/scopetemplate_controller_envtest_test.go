@@ -0,0 +1,122 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	operatorsv1 "awgreene/scope-operator/api/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	envtestTimeout  = 10 * time.Second
+	envtestInterval = 250 * time.Millisecond
+)
+
+// getGeneratedClusterRole fetches the single ClusterRole generated from st
+// for generateName, as identified by the labels ensureClusterRole sets.
+func getGeneratedClusterRole(st *operatorsv1.ScopeTemplate, generateName string, out *rbacv1.ClusterRole) error {
+	list := &rbacv1.ClusterRoleList{}
+	if err := k8sClient.List(ctx, list, client.MatchingLabels{
+		scopeTemplateUIDKey:    string(st.GetUID()),
+		clusterRoleGenerateKey: generateName,
+	}); err != nil {
+		return err
+	}
+	if len(list.Items) == 0 {
+		return apierrors.NewNotFound(rbacv1.Resource("clusterroles"), generateName)
+	}
+	*out = list.Items[0]
+	return nil
+}
+
+var _ = Describe("ScopeTemplateReconciler", func() {
+	var namespace *corev1.Namespace
+
+	BeforeEach(func() {
+		namespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "scope-template-"}}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(ctx, namespace)).To(Succeed())
+	})
+
+	It("generates, updates, and cleans up the ClusterRole described by its spec", func() {
+		st := &operatorsv1.ScopeTemplate{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "template-"},
+			Spec: operatorsv1.ScopeTemplateSpec{
+				ClusterRoles: []operatorsv1.ClusterRoleTemplate{{
+					GenerateName: "generated-role",
+					Rules:        []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, st)).To(Succeed())
+
+		si := &operatorsv1.ScopeInstance{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "instance-", Namespace: namespace.Name},
+			Spec: operatorsv1.ScopeInstanceSpec{
+				ScopeTemplateName: st.Name,
+				Namespaces:        []string{namespace.Name},
+				Subjects:          []rbacv1.Subject{adminSubject},
+			},
+		}
+		Expect(k8sClient.Create(ctx, si)).To(Succeed())
+
+		// add: a ScopeInstance referencing st causes its ClusterRole to be generated.
+		cr := &rbacv1.ClusterRole{}
+		Eventually(func() error {
+			return getGeneratedClusterRole(st, "generated-role", cr)
+		}, envtestTimeout, envtestInterval).Should(Succeed())
+		Expect(cr.Rules).To(Equal(st.Spec.ClusterRoles[0].Rules))
+
+		// update: adding a PolicyRule to the spec is reflected onto the existing ClusterRole.
+		Eventually(func() error {
+			latest := &operatorsv1.ScopeTemplate{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: st.Name}, latest); err != nil {
+				return err
+			}
+			latest.Spec.ClusterRoles[0].Rules = append(latest.Spec.ClusterRoles[0].Rules,
+				rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list"}})
+			return k8sClient.Update(ctx, latest)
+		}, envtestTimeout, envtestInterval).Should(Succeed())
+
+		Eventually(func() ([]rbacv1.PolicyRule, error) {
+			if err := getGeneratedClusterRole(st, "generated-role", cr); err != nil {
+				return nil, err
+			}
+			return cr.Rules, nil
+		}, envtestTimeout, envtestInterval).Should(HaveLen(2))
+
+		// delete: removing st cleans up the ClusterRole it generated.
+		Expect(k8sClient.Delete(ctx, st)).To(Succeed())
+		Eventually(func() error {
+			return getGeneratedClusterRole(st, "generated-role", cr)
+		}, envtestTimeout, envtestInterval).ShouldNot(Succeed())
+	})
+})
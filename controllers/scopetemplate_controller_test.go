@@ -0,0 +1,268 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	operatorsv1 "awgreene/scope-operator/api/v1"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestMapToScopeTemplate(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		obj  client.Object
+		want []reconcile.Request
+	}{
+		{
+			// Create events pass the ScopeInstance as it was created.
+			name: "create: ScopeInstance references a ScopeTemplate",
+			obj: &operatorsv1.ScopeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: "instance-a", Namespace: "team-a"},
+				Spec:       operatorsv1.ScopeInstanceSpec{ScopeTemplateName: "template-a"},
+			},
+			want: []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "template-a"}}},
+		},
+		{
+			// Update events invoke this func once with ObjectOld and once with
+			// ObjectNew; each call only ever sees one of the two names, which
+			// is why re-parenting requeues both old and new ScopeTemplate.
+			name: "update: new state references a different ScopeTemplate",
+			obj: &operatorsv1.ScopeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: "instance-a", Namespace: "team-a"},
+				Spec:       operatorsv1.ScopeInstanceSpec{ScopeTemplateName: "template-b"},
+			},
+			want: []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "template-b"}}},
+		},
+		{
+			// Delete events pass the ScopeInstance's last known state, which
+			// no longer exists to Get from the API server.
+			name: "delete: ScopeInstance no longer exists on the API server",
+			obj: &operatorsv1.ScopeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: "instance-a", Namespace: "team-a"},
+				Spec:       operatorsv1.ScopeInstanceSpec{ScopeTemplateName: "template-a"},
+			},
+			want: []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "template-a"}}},
+		},
+		{
+			name: "ScopeInstance does not reference a ScopeTemplate",
+			obj: &operatorsv1.ScopeInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: "instance-a", Namespace: "team-a"},
+			},
+			want: nil,
+		},
+		{
+			name: "obj is not a ScopeInstance",
+			obj:  &operatorsv1.ScopeTemplate{ObjectMeta: metav1.ObjectMeta{Name: "template-a"}},
+			want: nil,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ScopeTemplateReconciler{}
+			require.Equal(t, tt.want, r.mapToScopeTemplate(tt.obj))
+		})
+	}
+}
+
+// TestMapToScopeTemplate_Reparenting drives the real
+// handler.EnqueueRequestsFromMapFunc update path, rather than calling
+// mapToScopeTemplate directly, to confirm that re-parenting a ScopeInstance
+// from one ScopeTemplate to another requeues both: the old ScopeTemplate
+// (via ObjectOld), so it stops generating bindings on the instance's
+// behalf, and the new one (via ObjectNew), so it starts. No label ties
+// generated ClusterRoles/bindings back to a ScopeInstance post the
+// Subjects/Groups rework, so this old/new fan-out is what carries the old
+// ScopeTemplate forward instead.
+func TestMapToScopeTemplate_Reparenting(t *testing.T) {
+	r := &ScopeTemplateReconciler{}
+	eh := handler.EnqueueRequestsFromMapFunc(r.mapToScopeTemplate)
+
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	oldInstance := &operatorsv1.ScopeInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "instance-a", Namespace: "team-a"},
+		Spec:       operatorsv1.ScopeInstanceSpec{ScopeTemplateName: "template-a"},
+	}
+	newInstance := oldInstance.DeepCopy()
+	newInstance.Spec.ScopeTemplateName = "template-b"
+
+	eh.Update(event.UpdateEvent{ObjectOld: oldInstance, ObjectNew: newInstance}, q)
+
+	require.Equal(t, 2, q.Len())
+
+	seen := map[string]bool{}
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		seen[item.(reconcile.Request).Name] = true
+	}
+	require.Equal(t, map[string]bool{"template-a": true, "template-b": true}, seen)
+}
+
+func TestReconcileErrorReason(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "list error",
+			err:  withReason(operatorsv1.ReasonListError, errors.New("list scope instances: boom")),
+			want: operatorsv1.ReasonListError,
+		},
+		{
+			name: "duplicate GenerateName",
+			err:  withReason(operatorsv1.ReasonDuplicateGenerateName, errors.New("more than one ClusterRole found generated-role")),
+			want: operatorsv1.ReasonDuplicateGenerateName,
+		},
+		{
+			name: "reason survives an fmt.Errorf %w wrapper",
+			err:  fmt.Errorf("create ClusterRoles: %w", withReason(operatorsv1.ReasonHashMismatch, errors.New("boom"))),
+			want: operatorsv1.ReasonHashMismatch,
+		},
+		{
+			// An untyped error (e.g. an RBAC create/update failure or a quota
+			// error) must not be mislabeled as a list error.
+			name: "untyped error falls back to SyncFailed, not ListError",
+			err:  errors.New("exceeded quota"),
+			want: operatorsv1.ReasonSyncFailed,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, reconcileErrorReason(tt.err))
+		})
+	}
+}
+
+// TestUpdateStatus_SkipsNoopUpdate is a regression test for the
+// equality.Semantic.DeepEqual skip in updateStatus: without it, a status
+// Update bumps ResourceVersion and re-triggers a watch event, reconciling
+// forever even when nothing actually changed.
+func TestUpdateStatus_SkipsNoopUpdate(t *testing.T) {
+	ctx := context.Background()
+	st := newScopeTemplate("template-a")
+	r := &ScopeTemplateReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(st).Build()}
+
+	require.NoError(t, r.updateStatus(ctx, st, nil))
+	rv := st.ResourceVersion
+	require.NotEmpty(t, rv)
+
+	require.NoError(t, r.updateStatus(ctx, st, nil))
+	require.Equal(t, rv, st.ResourceVersion, "expected a second no-op updateStatus call not to persist a Status().Update")
+}
+
+// deleteErrorClient wraps a client.Client and forces Delete to fail for any
+// object whose name is in failNames, so tests can exercise cleanup paths
+// that must aggregate partial delete failures rather than bail on the first.
+type deleteErrorClient struct {
+	client.Client
+	failNames map[string]error
+}
+
+func (c *deleteErrorClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err, ok := c.failNames[obj.GetName()]; ok {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func TestCleanupGeneratedRBAC(t *testing.T) {
+	st := newScopeTemplate("template-a")
+	labels := map[string]string{scopeTemplateUIDKey: string(st.GetUID())}
+
+	cr := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "generated-role", Labels: labels}}
+	rb := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "generated-role-binding", Namespace: "team-a", Labels: labels}}
+	crb := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "generated-role-cluster-binding", Labels: labels}}
+	other := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "unrelated-role"}}
+
+	r := &ScopeTemplateReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(cr, rb, crb, other).Build()}
+
+	require.NoError(t, r.cleanupGeneratedRBAC(context.Background(), st.GetUID()))
+
+	require.True(t, isNotFound(r.Client.Get(context.Background(), types.NamespacedName{Name: cr.Name}, &rbacv1.ClusterRole{})))
+	require.True(t, isNotFound(r.Client.Get(context.Background(), types.NamespacedName{Name: rb.Name, Namespace: rb.Namespace}, &rbacv1.RoleBinding{})))
+	require.True(t, isNotFound(r.Client.Get(context.Background(), types.NamespacedName{Name: crb.Name}, &rbacv1.ClusterRoleBinding{})))
+
+	// Unrelated objects, not labeled with this ScopeTemplate's UID, are left alone.
+	require.NoError(t, r.Client.Get(context.Background(), types.NamespacedName{Name: other.Name}, &rbacv1.ClusterRole{}))
+}
+
+func TestCleanupGeneratedRBAC_AggregatesPartialFailures(t *testing.T) {
+	st := newScopeTemplate("template-a")
+	labels := map[string]string{scopeTemplateUIDKey: string(st.GetUID())}
+
+	cr := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "generated-role", Labels: labels}}
+	rb := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "generated-role-binding", Namespace: "team-a", Labels: labels}}
+	crb := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "generated-role-cluster-binding", Labels: labels}}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(cr, rb, crb).Build()
+	r := &ScopeTemplateReconciler{Client: &deleteErrorClient{
+		Client:    fakeClient,
+		failNames: map[string]error{rb.Name: errors.New("etcdserver: request timed out")},
+	}}
+
+	err := r.cleanupGeneratedRBAC(context.Background(), st.GetUID())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "request timed out")
+
+	// The failing RoleBinding delete must not stop the ClusterRole or
+	// ClusterRoleBinding from being cleaned up.
+	require.True(t, isNotFound(fakeClient.Get(context.Background(), types.NamespacedName{Name: cr.Name}, &rbacv1.ClusterRole{})))
+	require.True(t, isNotFound(fakeClient.Get(context.Background(), types.NamespacedName{Name: crb.Name}, &rbacv1.ClusterRoleBinding{})))
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: rb.Name, Namespace: rb.Namespace}, &rbacv1.RoleBinding{}))
+}
+
+// TestScopeTemplateReconcile_Deletion drives Reconcile's DeletionTimestamp
+// branch directly (rather than cleanupGeneratedRBAC in isolation) to confirm
+// the finalizer is removed and the generated RBAC is gone by the time it is.
+func TestScopeTemplateReconcile_Deletion(t *testing.T) {
+	ctx := context.Background()
+	st := newScopeTemplate("template-a")
+	st.Finalizers = []string{scopeCleanupFinalizer}
+
+	cr := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{
+		Name:   "generated-role",
+		Labels: map[string]string{scopeTemplateUIDKey: string(st.GetUID())},
+	}}
+
+	r := &ScopeTemplateReconciler{Client: fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(st, cr).Build()}
+
+	require.NoError(t, r.Client.Delete(ctx, st))
+
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: st.Name}})
+	require.NoError(t, err)
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: st.Name}, &operatorsv1.ScopeTemplate{})
+	require.True(t, isNotFound(err), "expected ScopeTemplate to be gone once its finalizer is removed, got err=%v", err)
+
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cr.Name}, &rbacv1.ClusterRole{})
+	require.True(t, isNotFound(err), "expected generated ClusterRole to be cleaned up, got err=%v", err)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HashObject returns a stable short hash of obj's JSON representation. It is
+// used to detect drift between the spec that produced a generated object and
+// the spec currently on the owning resource.
+func HashObject(obj interface{}) string {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%x", sum)[:32]
+}
+
+// IsOwnedByLabel returns true if the given object's owner-tracking labels
+// match the UID of owner.
+func IsOwnedByLabel(obj metav1.Object, owner metav1.Object) bool {
+	labels := obj.GetLabels()
+	if labels == nil {
+		return false
+	}
+	return labels["operators.coreos.io/scopeTemplateUID"] == string(owner.GetUID())
+}
+
+// DedupeSubjects returns subjects sorted and with duplicates removed, so that
+// collapsing the Subjects contributed by several ScopeInstances into a
+// single binding converges on the same result regardless of the order they
+// were collected in.
+func DedupeSubjects(subjects []rbacv1.Subject) []rbacv1.Subject {
+	if len(subjects) == 0 {
+		return nil
+	}
+
+	sorted := make([]rbacv1.Subject, len(subjects))
+	copy(sorted, subjects)
+	sort.Slice(sorted, func(i, j int) bool {
+		return subjectKey(sorted[i]) < subjectKey(sorted[j])
+	})
+
+	deduped := sorted[:0]
+	for i, s := range sorted {
+		if i == 0 || subjectKey(s) != subjectKey(sorted[i-1]) {
+			deduped = append(deduped, s)
+		}
+	}
+	return deduped
+}
+
+// subjectKey returns a string uniquely identifying a Subject by its Kind,
+// APIGroup, Namespace and Name.
+func subjectKey(s rbacv1.Subject) string {
+	return strings.Join([]string{s.Kind, s.APIGroup, s.Namespace, s.Name}, "/")
+}